@@ -0,0 +1,193 @@
+// Package app wires the guest circuit defined in the brevis package into the
+// standard Brevis app workflow, turning it from an isolated circuit example
+// into a service the prediction-market backend can call directly: fetch the
+// on-chain data a query needs, prove the circuit over it, and submit the
+// resulting proof for on-chain settlement.
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brevis-network/brevis-sdk/sdk"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/professortX/prediction-market/brevis"
+)
+
+// uint248ToAddress recovers the common.Address a sdk.Uint248 circuit constant
+// was built from. Converting via the big.Int representation (rather than a
+// raw byte-slice cast) is required because Uint248.Bytes() is not guaranteed
+// to be exactly 20 bytes.
+func uint248ToAddress(v sdk.Uint248) common.Address {
+	return common.BigToAddress(v.BigInt())
+}
+
+// uint248ToHash recovers the common.Hash (e.g. an event ID) a sdk.Uint248
+// circuit constant was built from.
+func uint248ToHash(v sdk.Uint248) common.Hash {
+	return common.BigToHash(v.BigInt())
+}
+
+// findLogPos returns the index within logs of the first log emitted by
+// contract whose first topic (the event ID) is eventID.
+func findLogPos(logs []*types.Log, contract common.Address, eventID common.Hash) (int, error) {
+	for i, log := range logs {
+		if log.Address == contract && len(log.Topics) > 0 && log.Topics[0] == eventID {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no log from %s with event %s", contract, eventID)
+}
+
+// Proof is the final Brevis proof produced by Service.Prove, ready to be
+// handed to Service.Submit for on-chain settlement.
+type Proof struct {
+	// Witness is the circuit's public witness, required alongside Proof by the
+	// on-chain verifier contract.
+	Witness []byte
+	// Proof is the raw SNARK proof bytes produced by the prover.
+	Proof []byte
+}
+
+// Service fetches the on-chain data required by brevis.AppCircuit, proves it,
+// and submits the resulting proof on-chain. One Service handles one query at
+// a time: call Prepare, then Prove, then Submit.
+type Service struct {
+	client  *ethclient.Client
+	circuit *brevis.AppCircuit
+
+	brevisApp *sdk.BrevisApp
+	compiled  sdk.CompiledCircuit
+	proof     *Proof
+}
+
+// NewService constructs a Service that fetches chain data from client and
+// proves circuit. Pass brevis.DefaultAppCircuit() to reproduce the example
+// USDC/ETH configuration, or a custom AppCircuit for another outcome-token/
+// collateral pair.
+func NewService(client *ethclient.Client, circuit *brevis.AppCircuit) (*Service, error) {
+	brevisApp, err := sdk.NewBrevisApp(client)
+	if err != nil {
+		return nil, fmt.Errorf("brevis/app: new brevis app: %w", err)
+	}
+	return &Service{client: client, circuit: circuit, brevisApp: brevisApp}, nil
+}
+
+// Prepare stages the receipts for txHashes and both configured users'
+// outcome-token balances as the circuit's data source for the next Prove
+// call. userAddr must be one of s.circuit's two configured users; it exists
+// to catch callers querying the wrong circuit instance, not to select which
+// user's balance gets staged, since the circuit always attests both.
+func (s *Service) Prepare(userAddr common.Address, txHashes []common.Hash) error {
+	if err := s.assertConfiguredUser(userAddr); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	poolAddress := uint248ToAddress(s.circuit.PoolAddress)
+	quoteToken := uint248ToAddress(s.circuit.QuoteToken)
+	swapEventID := uint248ToHash(s.circuit.EventIdSwap)
+	transferEventID := uint248ToHash(s.circuit.EventIdTransfer)
+
+	for _, txHash := range txHashes {
+		receipt, err := s.client.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return fmt.Errorf("brevis/app: fetch receipt %s: %w", txHash, err)
+		}
+
+		// Locate the Swap and Transfer logs within the receipt so we can tell
+		// Brevis exactly which log/field each of the circuit's three asserted
+		// Fields (Swap.amount0, Swap.recipient, Transfer.from) comes from.
+		swapLogPos, err := findLogPos(receipt.Logs, poolAddress, swapEventID)
+		if err != nil {
+			return fmt.Errorf("brevis/app: locate Swap log in %s: %w", txHash, err)
+		}
+		transferLogPos, err := findLogPos(receipt.Logs, quoteToken, transferEventID)
+		if err != nil {
+			return fmt.Errorf("brevis/app: locate Transfer log in %s: %w", txHash, err)
+		}
+
+		if _, err := s.brevisApp.AddReceipt(sdk.ReceiptData{
+			TxHash: txHash,
+			Fields: [sdk.NumMaxLogFields]sdk.LogFieldData{
+				{LogPos: swapLogPos, IsTopic: false, FieldIndex: 0},    // Swap.amount0
+				{LogPos: swapLogPos, IsTopic: true, FieldIndex: 2},     // Swap.recipient
+				{LogPos: transferLogPos, IsTopic: true, FieldIndex: 1}, // Transfer.from
+			},
+		}); err != nil {
+			return fmt.Errorf("brevis/app: add receipt %s: %w", txHash, err)
+		}
+	}
+
+	for i, slotKey := range s.circuit.BalanceSlotKeys {
+		if _, err := s.brevisApp.AddStorage(sdk.StorageData{
+			Address:    uint248ToAddress(s.circuit.OutcomeToken),
+			StorageKey: common.Hash(slotKey.Bytes()),
+		}); err != nil {
+			return fmt.Errorf("brevis/app: add storage slot for %s: %w", uint248ToAddress(s.circuit.UserAddr[i]), err)
+		}
+	}
+	return nil
+}
+
+// assertConfiguredUser rejects a userAddr that is not one of s.circuit's two
+// configured users, since staging data for a user the circuit doesn't know
+// about would silently prove the wrong query.
+func (s *Service) assertConfiguredUser(userAddr common.Address) error {
+	for _, u := range s.circuit.UserAddr {
+		if uint248ToAddress(u) == userAddr {
+			return nil
+		}
+	}
+	return fmt.Errorf("brevis/app: %s is not one of this circuit's configured users", userAddr)
+}
+
+// Prove compiles the circuit, builds the witness over the data staged by
+// Prepare, and runs the prover. The compiled circuit is cached on s so
+// repeated calls to Prove across queries only pay the compilation cost once.
+func (s *Service) Prove(ctx context.Context) (Proof, error) {
+	circuitInput, err := s.brevisApp.BuildCircuitInput(ctx, s.circuit)
+	if err != nil {
+		return Proof{}, fmt.Errorf("brevis/app: build circuit input: %w", err)
+	}
+
+	if s.compiled == nil {
+		compiled, err := sdk.Compile(s.circuit, circuitInput)
+		if err != nil {
+			return Proof{}, fmt.Errorf("brevis/app: compile circuit: %w", err)
+		}
+		s.compiled = compiled
+	}
+
+	witness, publicWitness, err := s.compiled.NewFullWitness(s.circuit, circuitInput)
+	if err != nil {
+		return Proof{}, fmt.Errorf("brevis/app: build witness: %w", err)
+	}
+
+	proofBytes, err := s.compiled.Prove(witness)
+	if err != nil {
+		return Proof{}, fmt.Errorf("brevis/app: prove: %w", err)
+	}
+
+	proof := Proof{Witness: publicWitness, Proof: proofBytes}
+	s.proof = &proof
+	return proof, nil
+}
+
+// Submit hands the proof produced by the most recent Prove call to the
+// Brevis gateway, which relays it to verifierContract for on-chain
+// settlement, and returns the resulting transaction hash.
+func (s *Service) Submit(ctx context.Context, verifierContract common.Address) (common.Hash, error) {
+	if s.proof == nil {
+		return common.Hash{}, fmt.Errorf("brevis/app: submit called before a successful Prove")
+	}
+
+	txHash, err := s.brevisApp.SubmitProof(ctx, verifierContract, s.proof.Proof, s.proof.Witness)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("brevis/app: submit proof: %w", err)
+	}
+	return txHash, nil
+}
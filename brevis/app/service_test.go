@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/professortX/prediction-market/brevis"
+)
+
+func TestFindLogPos(t *testing.T) {
+	contract := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	eventID := common.HexToHash("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	otherEventID := common.HexToHash("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+
+	logs := []*types.Log{
+		{Address: other, Topics: []common.Hash{otherEventID}},
+		{Address: contract, Topics: []common.Hash{eventID}},
+	}
+
+	pos, err := findLogPos(logs, contract, eventID)
+	if err != nil {
+		t.Fatalf("findLogPos: unexpected error: %v", err)
+	}
+	if pos != 1 {
+		t.Fatalf("findLogPos: got position %d, want 1", pos)
+	}
+
+	if _, err := findLogPos(logs, contract, otherEventID); err == nil {
+		t.Fatal("findLogPos: expected error when contract has no log with the given event ID, got nil")
+	}
+}
+
+func TestServiceAssertConfiguredUser(t *testing.T) {
+	circuit := brevis.DefaultAppCircuit()
+	s := &Service{circuit: circuit}
+
+	member := uint248ToAddress(circuit.UserAddr[0])
+	if err := s.assertConfiguredUser(member); err != nil {
+		t.Fatalf("assertConfiguredUser: unexpected error for a configured user: %v", err)
+	}
+
+	stranger := common.HexToAddress("0x0000000000000000000000000000000000000f")
+	if err := s.assertConfiguredUser(stranger); err == nil {
+		t.Fatal("assertConfiguredUser: expected error for an unconfigured user, got nil")
+	}
+}
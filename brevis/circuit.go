@@ -1,12 +1,16 @@
 package brevis
 
 import (
+	"math/big"
+
 	"github.com/brevis-network/brevis-sdk/sdk"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// This example circuit analyzes the swap events between USDC and ETH/WETH for a user.
+// This example circuit analyzes the swap events between a pool's quote token
+// and an arbitrary outcome token for a user.
 
 // AppCircuit is a developer-defined circuit that performs checks and data analysis
 // over the input Receipt. The proof of this circuit is to be verified in Brevis
@@ -17,6 +21,36 @@ type AppCircuit struct {
 	// You can define your own custom circuit inputs here, but note that they cannot
 	// have the `gnark:",public"` tag.
 	UserAddr [2]sdk.Uint248
+
+	// PoolAddress is the Uniswap pool whose Swap events are being analyzed. This
+	// lets the same compiled circuit prove trades for any outcome-token/collateral
+	// pair instead of a single hardcoded pool.
+	PoolAddress sdk.Uint248
+	// QuoteToken is the collateral (e.g. USDC) side of the pool, i.e. the contract
+	// that emits the Transfer event consulted for native-out swaps.
+	QuoteToken sdk.Uint248
+	// Router is the Uniswap router address used to detect native-out swaps.
+	Router sdk.Uint248
+	// EventIdSwap and EventIdTransfer are the event IDs of the Swap and Transfer
+	// events respectively, parameterized so callers can target pools whose events
+	// have other IDs. The field layout Define asserts is fixed: amount0 is data
+	// field 0 and recipient topic 2 of the Swap event, from is topic 1 of the
+	// Transfer event.
+	EventIdSwap     sdk.Uint248
+	EventIdTransfer sdk.Uint248
+
+	// OutcomeToken is the ERC-20 outcome-token contract whose balanceOf storage
+	// is attested, so a downstream resolver can settle payouts against
+	// Brevis-verified positions instead of trusting an RPC.
+	OutcomeToken sdk.Uint248
+	// BalanceSlotKeys are the pre-computed balanceOf(UserAddr[i]) storage slot
+	// keys for OutcomeToken, one per entry in UserAddr. See balanceOfSlotKey.
+	BalanceSlotKeys [2]sdk.Bytes32
+
+	// StartBlock and EndBlock bound the epoch over which swap volume is
+	// aggregated, e.g. a trading-competition window or fee-rebate period.
+	StartBlock sdk.Uint248
+	EndBlock   sdk.Uint248
 }
 
 var from = common.HexToAddress("0xaefB31e9EEee2822f4C1cBC13B70948b0B5C0b3c")
@@ -24,7 +58,19 @@ var from2 = common.HexToAddress("0x3195ee2A3c4Cc67f448767faAdb061472e670223")
 
 func DefaultAppCircuit() *AppCircuit {
 	return &AppCircuit{
-		UserAddr: [2]sdk.Uint248{sdk.ConstUint248(from), sdk.ConstUint248(from2)},
+		UserAddr:        [2]sdk.Uint248{sdk.ConstUint248(from), sdk.ConstUint248(from2)},
+		PoolAddress:     UsdcPoolAddress,
+		QuoteToken:      UsdcAddress,
+		Router:          RouterAddress,
+		EventIdSwap:     EventIdSwap,
+		EventIdTransfer: EventIdTransfer,
+		OutcomeToken:    OutcomeTokenAddress,
+		BalanceSlotKeys: [2]sdk.Bytes32{
+			sdk.ConstBytes32(balanceOfSlotKey(from, BalanceOfSlotIndex).Bytes()),
+			sdk.ConstBytes32(balanceOfSlotKey(from2, BalanceOfSlotIndex).Bytes()),
+		},
+		StartBlock: DefaultStartBlock,
+		EndBlock:   DefaultEndBlock,
 	}
 }
 
@@ -35,6 +81,11 @@ var _ sdk.AppCircuit = &AppCircuit{}
 // Note that you can only use these outside of circuit (making constant circuit
 // variables)
 
+// The constants below are kept as the defaults for the USDC/ETH pool and are
+// wired into AppCircuit by DefaultAppCircuit. Callers targeting a different
+// outcome-token/collateral pair should construct an AppCircuit directly with
+// their own PoolAddress, QuoteToken, Router and event IDs instead.
+
 var EventIdSwap = sdk.ParseEventID(
 	hexutil.MustDecode("0xc42079f94a6350d7e6235f29174924f928cc2ac818eb64fed8004e115fbcca67"))
 var EventIdTransfer = sdk.ParseEventID(
@@ -49,14 +100,36 @@ var UsdcAddress = sdk.ConstUint248(
 var Salt = sdk.ConstBytes32(
 	hexutil.MustDecode("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"))
 
+var OutcomeTokenAddress = sdk.ConstUint248(
+	common.HexToAddress("0xB4FBF271143F4FBf7B91A5ded31805e42b2208d6"))
+
+// DefaultStartBlock and DefaultEndBlock bound the example epoch used by
+// DefaultAppCircuit. Real deployments should construct an AppCircuit with the
+// actual competition or rebate window instead.
+var DefaultStartBlock = sdk.ConstUint248(0)
+var DefaultEndBlock = sdk.ConstUint248(1_000_000_000)
+
+// BalanceOfSlotIndex is the storage slot index of the `balanceOf` mapping in
+// the ERC-20 outcome-token contract (slot 0 for most standard OpenZeppelin
+// token implementations).
+const BalanceOfSlotIndex = 0
+
+// balanceOfSlotKey computes the storage slot key of `balanceOf[userAddr]` for
+// an ERC-20 contract whose `balanceOf` mapping lives at mappingSlot, following
+// solidity's standard mapping storage layout: keccak256(pad32(key) ++ pad32(slot)).
+func balanceOfSlotKey(userAddr common.Address, mappingSlot int) common.Hash {
+	data := append(common.LeftPadBytes(userAddr.Bytes(), 32), common.LeftPadBytes(big.NewInt(int64(mappingSlot)).Bytes(), 32)...)
+	return crypto.Keccak256Hash(data)
+}
+
 func (c *AppCircuit) Allocate() (maxReceipts, maxSlots, maxTransactions int) {
 	// Allocating regions for different source data. Here, we are allocating 5 data
-	// slots for "receipt" data, and none for other data types. Please note that if
-	// you allocate it this way and compile your circuit, the circuit structure will
-	// always have 5 processing "chips" for receipts and none for others. It means
-	// your compiled circuit will always be only able to process up to 5 receipts and
-	// cannot process other types unless you change the allocations and recompile.
-	return 5, 0, 0
+	// slots for "receipt" data and 2 slots for "storage" data (one balanceOf read
+	// per configured user), and none for transactions. Please note that if you
+	// allocate it this way and compile your circuit, the circuit structure will
+	// always have these fixed processing "chips" and cannot process other
+	// quantities unless you change the allocations and recompile.
+	return 5, 2, 0
 }
 
 func (c *AppCircuit) Define(api *sdk.CircuitAPI, in sdk.DataInput) error {
@@ -70,17 +143,26 @@ func (c *AppCircuit) Define(api *sdk.CircuitAPI, in sdk.DataInput) error {
 		// If the recipient field of the Swap event is uniswap router, it means the user
 		// requested native token out. We need to instead check the user's address in the
 		// Transfer event emitted by USDC contract
-		u248.IsEqual(api.ToUint248(l.Fields[1].Value), RouterAddress)
+		isNativeOut := u248.IsEqual(api.ToUint248(l.Fields[1].Value), c.Router)
+		identity := u248.Select(isNativeOut,
+			api.ToUint248(l.Fields[2].Value), // Transfer.from, when the user traded out to native token
+			api.ToUint248(l.Fields[1].Value), // Swap.recipient, otherwise
+		)
+		identityIsConfiguredUser := u248.Or(
+			u248.IsEqual(identity, c.UserAddr[0]),
+			u248.IsEqual(identity, c.UserAddr[1]),
+		)
 
 		assertionPassed := u248.And(
+			identityIsConfiguredUser,
 			// Check that the contract address of each log field is the expected contract
-			u248.IsEqual(l.Fields[0].Contract, UsdcPoolAddress),
-			u248.IsEqual(l.Fields[1].Contract, UsdcPoolAddress),
-			u248.IsEqual(l.Fields[2].Contract, UsdcAddress),
+			u248.IsEqual(l.Fields[0].Contract, c.PoolAddress),
+			u248.IsEqual(l.Fields[1].Contract, c.PoolAddress),
+			u248.IsEqual(l.Fields[2].Contract, c.QuoteToken),
 			// Check the EventID of the fields are as expected
-			u248.IsEqual(l.Fields[0].EventID, EventIdSwap),
-			u248.IsEqual(l.Fields[1].EventID, EventIdSwap),
-			u248.IsEqual(l.Fields[2].EventID, EventIdTransfer),
+			u248.IsEqual(l.Fields[0].EventID, c.EventIdSwap),
+			u248.IsEqual(l.Fields[1].EventID, c.EventIdSwap),
+			u248.IsEqual(l.Fields[2].EventID, c.EventIdTransfer),
 			// Check the index of the fields are as expected
 			u248.IsZero(l.Fields[0].IsTopic),                     // `amount0` is not a topic field
 			u248.IsEqual(l.Fields[0].Index, sdk.ConstUint248(0)), // `amount0` is the 0th data field in the `Swap` event
@@ -92,8 +174,39 @@ func (c *AppCircuit) Define(api *sdk.CircuitAPI, in sdk.DataInput) error {
 		return assertionPassed
 	})
 
+	// Aggregate swap volume over [StartBlock, EndBlock]; see StartBlock's doc
+	// comment for what the window is used for.
+	inWindow := sdk.Filter(receipts, func(l sdk.Receipt) sdk.Uint248 {
+		blockNum := api.ToUint248(l.BlockNum)
+		return u248.And(
+			u248.IsLessThanOrEqual(c.StartBlock, blockNum),
+			u248.IsLessThanOrEqual(blockNum, c.EndBlock),
+		)
+	})
+	volumes := sdk.Map(inWindow, func(l sdk.Receipt) sdk.Uint248 {
+		amount0 := api.ToInt248(l.Fields[0].Value)
+		return api.ToUint248(api.Int248.ABS(amount0))
+	})
+	totalVolume := sdk.Sum(volumes)
+	api.OutputUint(248, totalVolume)
+
+	// Attest to each user's outstanding position in OutcomeToken at the proven
+	// block. Slot i is required to be exactly user i's balanceOf slot (not
+	// merely one of the two configured slots): an unordered membership check
+	// would let a prover submit the same slot for both allocated positions,
+	// double-counting one user's balance while silently dropping the other's.
+	var balances [2]sdk.Uint248
+	for i := range c.UserAddr {
+		slot := in.StorageSlots[i]
+		api.AssertIsEqual(u248.IsEqual(slot.Contract, c.OutcomeToken), sdk.ConstUint248(1))
+		api.AssertIsEqual(api.Bytes32.IsEqual(slot.Key, c.BalanceSlotKeys[i]), sdk.ConstUint248(1))
+		balances[i] = api.ToUint248(slot.Value)
+	}
+	totalBalance := u248.Add(balances[0], balances[1])
+
 	for _, uint248 := range c.UserAddr {
 		api.OutputAddress(uint248)
 	}
+	api.OutputUint(248, totalBalance)
 	return nil
 }
@@ -0,0 +1,131 @@
+package brevis
+
+import (
+	"testing"
+
+	"github.com/brevis-network/brevis-sdk/sdk"
+	"github.com/brevis-network/brevis-sdk/test"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// storageSlot builds an sdk.StorageSlot test double for contract's storage at key.
+func storageSlot(contract sdk.Uint248, key common.Hash, value uint64) sdk.StorageSlot {
+	return sdk.StorageSlot{
+		Contract: contract,
+		Key:      sdk.ConstBytes32(key.Bytes()),
+		Value:    sdk.ConstUint248(value),
+	}
+}
+
+// validStorageSlots returns the two balanceOf slots DefaultAppCircuit expects,
+// in the positional order it expects them (slot i for UserAddr[i]).
+func validStorageSlots() []sdk.StorageSlot {
+	return []sdk.StorageSlot{
+		storageSlot(OutcomeTokenAddress, balanceOfSlotKey(from, BalanceOfSlotIndex), 100),
+		storageSlot(OutcomeTokenAddress, balanceOfSlotKey(from2, BalanceOfSlotIndex), 200),
+	}
+}
+
+func newTestInput(receipts []sdk.Receipt, slots []sdk.StorageSlot) sdk.CircuitInput {
+	return sdk.CircuitInput{
+		DataInput: sdk.DataInput{
+			Receipts:     receipts,
+			StorageSlots: slots,
+		},
+	}
+}
+
+func TestCircuit_BalanceSlots_CorrectPositionalAssignmentSucceeds(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	in := newTestInput(nil, validStorageSlots())
+	test.ProverSucceeded(t, circuit, in)
+}
+
+func TestCircuit_BalanceSlots_RejectsDoubleCountedSlot(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	user0Slot := storageSlot(OutcomeTokenAddress, balanceOfSlotKey(from, BalanceOfSlotIndex), 100)
+	// Both allocated slots claim to be UserAddr[0]'s balance: with the old
+	// OR-membership check this passed and double-counted user 0's balance
+	// while dropping user 1's entirely.
+	in := newTestInput(nil, []sdk.StorageSlot{user0Slot, user0Slot})
+	test.ProverFailed(t, circuit, in)
+}
+
+func TestCircuit_BalanceSlots_RejectsSwappedOrder(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	slots := validStorageSlots()
+	in := newTestInput(nil, []sdk.StorageSlot{slots[1], slots[0]})
+	test.ProverFailed(t, circuit, in)
+}
+
+// swapReceipt builds a receipt whose three Fields match the Contract/EventID/
+// IsTopic/Index layout Define asserts: Swap.amount0, Swap.recipient and
+// Transfer.from.
+func swapReceipt(recipient, transferFrom common.Address) sdk.Receipt {
+	return sdk.Receipt{
+		Fields: [3]sdk.LogField{
+			{
+				Contract: UsdcPoolAddress,
+				EventID:  EventIdSwap,
+				IsTopic:  sdk.ConstUint248(0),
+				Index:    sdk.ConstUint248(0),
+				Value:    sdk.ConstUint248(5),
+			},
+			{
+				Contract: UsdcPoolAddress,
+				EventID:  EventIdSwap,
+				IsTopic:  sdk.ConstUint248(1),
+				Index:    sdk.ConstUint248(2),
+				Value:    sdk.ConstUint248(recipient),
+			},
+			{
+				Contract: UsdcAddress,
+				EventID:  EventIdTransfer,
+				IsTopic:  sdk.ConstUint248(1),
+				Index:    sdk.ConstUint248(1),
+				Value:    sdk.ConstUint248(transferFrom),
+			},
+		},
+	}
+}
+
+func TestCircuit_Identity_TokenOutUsesSwapRecipient(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	stranger := common.HexToAddress("0x000000000000000000000000000000000000ff")
+	// recipient == from (a configured user), not the router: token-out case.
+	// Transfer.from is not a configured user, so this passes only if the
+	// identity is taken from Swap.recipient.
+	receipt := swapReceipt(from, stranger)
+	in := newTestInput([]sdk.Receipt{receipt}, validStorageSlots())
+	test.ProverSucceeded(t, circuit, in)
+}
+
+func TestCircuit_Identity_NativeOutUsesTransferFrom(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	routerAddr := common.HexToAddress("0xEf1c6E67703c7BD7107eed8303Fbe6EC2554BF6B")
+	// recipient == router: native-out case, identity must come from Transfer.from.
+	receipt := swapReceipt(routerAddr, from2)
+	in := newTestInput([]sdk.Receipt{receipt}, validStorageSlots())
+	test.ProverSucceeded(t, circuit, in)
+}
+
+func TestCircuit_Identity_RejectsUnconfiguredTokenOutRecipient(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	stranger := common.HexToAddress("0x000000000000000000000000000000000000ff")
+	// recipient is neither the router nor a configured user: must be rejected.
+	receipt := swapReceipt(stranger, from2)
+	in := newTestInput([]sdk.Receipt{receipt}, validStorageSlots())
+	test.ProverFailed(t, circuit, in)
+}
+
+func TestCircuit_Identity_RejectsUnconfiguredNativeOutTransferFrom(t *testing.T) {
+	circuit := DefaultAppCircuit()
+	routerAddr := common.HexToAddress("0xEf1c6E67703c7BD7107eed8303Fbe6EC2554BF6B")
+	stranger := common.HexToAddress("0x000000000000000000000000000000000000ff")
+	// recipient == router but Transfer.from is not a configured user: must be
+	// rejected even though the Swap.recipient alone (the router) is never
+	// checked against UserAddr.
+	receipt := swapReceipt(routerAddr, stranger)
+	in := newTestInput([]sdk.Receipt{receipt}, validStorageSlots())
+	test.ProverFailed(t, circuit, in)
+}